@@ -0,0 +1,207 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CheckFunc is a single readiness/liveness probe. It should return promptly
+// and return a non-nil error if the thing it checks is unhealthy.
+type CheckFunc func(ctx context.Context) error
+
+// CheckKind classifies what a failing check should mean to its caller.
+// CheckReadiness means "pull this replica out of rotation": a dependency
+// like etcd/DB connectivity or storage aggregator lag that the orchestrator
+// can recover from without restarting. CheckLiveness means "the process
+// itself is broken": only these gate LiveHandler, since a real liveness
+// probe restarts the process on failure, and a recoverable dependency
+// outage shouldn't cause a restart loop.
+type CheckKind int
+
+const (
+	CheckReadiness CheckKind = iota
+	CheckLiveness
+)
+
+// checkStatus is the last observed outcome of a registered check.
+type checkStatus struct {
+	name        string
+	kind        CheckKind
+	ttl         time.Duration
+	lastSuccess time.Time
+	lastError   string
+}
+
+func (s *checkStatus) healthy() bool {
+	if s.lastSuccess.IsZero() {
+		return false
+	}
+	return s.lastError == "" && time.Since(s.lastSuccess) <= s.ttl
+}
+
+// CheckResult is the JSON representation of a single check's status.
+type CheckResult struct {
+	Healthy     bool      `json:"healthy"`
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// AggregateResult is the JSON body served by /health/live and /health/ready.
+type AggregateResult struct {
+	Healthy bool                   `json:"healthy"`
+	Checks  map[string]CheckResult `json:"checks"`
+}
+
+// HealthAggregator composes per-registrant heartbeat state with arbitrary
+// named checks (etcd/DB connectivity, storage aggregator lag, ...) and
+// serves the combined result as JSON over HTTP.
+type HealthAggregator struct {
+	mu       sync.Mutex
+	statuses map[string]*checkStatus
+	cancels  map[string]context.CancelFunc
+
+	registry *Registry
+}
+
+// heartbeatCheckInterval is how often the built-in "heartbeats" check polls
+// the registry's aggregate heartbeat state.
+const heartbeatCheckInterval = 5 * time.Second
+
+// NewHealthAggregator creates a HealthAggregator whose readiness view
+// includes the heartbeat state tracked by reg, exposed as a built-in
+// "heartbeats" check.
+func NewHealthAggregator(reg *Registry) *HealthAggregator {
+	a := &HealthAggregator{
+		statuses: make(map[string]*checkStatus),
+		cancels:  make(map[string]context.CancelFunc),
+		registry: reg,
+	}
+
+	_ = a.RegisterCheck("heartbeats", heartbeatCheckInterval, CheckReadiness, func(_ context.Context) error {
+		return reg.Healthy()
+	})
+
+	return a
+}
+
+// RegisterCheck schedules fn to run every interval and folds its result into
+// the aggregate, classified per kind. A check is considered failing if its
+// latest run returned an error, it has never succeeded, or its last success
+// is older than interval * 2 (its TTL).
+func (a *HealthAggregator) RegisterCheck(name string, interval time.Duration, kind CheckKind, fn CheckFunc) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.statuses[name]; exists {
+		return fmt.Errorf("check %s is already registered", name)
+	}
+
+	status := &checkStatus{name: name, kind: kind, ttl: interval * 2}
+	a.statuses[name] = status
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancels[name] = cancel
+
+	go a.runCheck(ctx, status, interval, fn)
+
+	return nil
+}
+
+func (a *HealthAggregator) runCheck(ctx context.Context, status *checkStatus, interval time.Duration, fn CheckFunc) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	a.evaluate(ctx, status, fn)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.evaluate(ctx, status, fn)
+		}
+	}
+}
+
+func (a *HealthAggregator) evaluate(ctx context.Context, status *checkStatus, fn CheckFunc) {
+	err := fn(ctx)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err != nil {
+		status.lastError = err.Error()
+		return
+	}
+	status.lastSuccess = time.Now()
+	status.lastError = ""
+}
+
+// Shutdown stops every registered check.
+func (a *HealthAggregator) Shutdown() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for name, cancel := range a.cancels {
+		cancel()
+		delete(a.cancels, name)
+	}
+}
+
+// snapshot aggregates the registered checks into a result. When liveOnly is
+// true, only CheckLiveness checks are folded in, so a failing
+// CheckReadiness dependency (etcd/DB connectivity, storage aggregator lag,
+// ...) can't flip /health/live and trigger a process restart over something
+// a restart wouldn't fix.
+func (a *HealthAggregator) snapshot(liveOnly bool) AggregateResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := AggregateResult{Healthy: true, Checks: make(map[string]CheckResult, len(a.statuses))}
+
+	for name, status := range a.statuses {
+		if liveOnly && status.kind != CheckLiveness {
+			continue
+		}
+
+		healthy := status.healthy()
+		result.Checks[name] = CheckResult{
+			Healthy:     healthy,
+			LastSuccess: status.lastSuccess,
+			LastError:   status.lastError,
+		}
+		if !healthy {
+			result.Healthy = false
+		}
+	}
+
+	return result
+}
+
+func writeAggregateResult(w http.ResponseWriter, result AggregateResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// LiveHandler serves /health/live: whether the orchestrator process itself
+// is up, based only on its CheckLiveness checks. A failing CheckReadiness
+// dependency must not 503 this endpoint, since a real liveness probe reacts
+// to a 503 by restarting the process.
+func (a *HealthAggregator) LiveHandler(w http.ResponseWriter, _ *http.Request) {
+	writeAggregateResult(w, a.snapshot(true))
+}
+
+// ReadyHandler serves /health/ready: whether this replica should receive
+// traffic, folding in every registered check (CheckReadiness and
+// CheckLiveness alike), since a replica isn't ready to serve if it isn't
+// alive either.
+func (a *HealthAggregator) ReadyHandler(w http.ResponseWriter, _ *http.Request) {
+	writeAggregateResult(w, a.snapshot(false))
+}
@@ -0,0 +1,147 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func decodeAggregateResult(t *testing.T, rec *httptest.ResponseRecorder) AggregateResult {
+	t.Helper()
+	var out AggregateResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decoding response body: %v, body=%s", err, rec.Body.String())
+	}
+	return out
+}
+
+func TestHealthAggregator_AllHealthy(t *testing.T) {
+	a := &HealthAggregator{
+		statuses: make(map[string]*checkStatus),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+	if err := a.RegisterCheck("ok", time.Minute, CheckReadiness, func(context.Context) error {
+		return nil
+	}); err != nil {
+		t.Fatalf("RegisterCheck: %v", err)
+	}
+	defer a.Shutdown()
+
+	waitForCheck(t, a, "ok")
+
+	for _, handler := range map[string]http.HandlerFunc{"live": a.LiveHandler, "ready": a.ReadyHandler} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		body := decodeAggregateResult(t, rec)
+		if !body.Healthy {
+			t.Fatalf("body.Healthy = false, want true: %+v", body)
+		}
+	}
+}
+
+// TestHealthAggregator_ReadinessFailureDoesNotFailLiveness pins the core
+// fix: a failing CheckReadiness check (e.g. etcd/DB connectivity) must 503
+// /health/ready but leave /health/live at 200, since a liveness probe
+// reacting to a dependency outage by restarting the process doesn't fix
+// anything.
+func TestHealthAggregator_ReadinessFailureDoesNotFailLiveness(t *testing.T) {
+	a := &HealthAggregator{
+		statuses: make(map[string]*checkStatus),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+	if err := a.RegisterCheck("etcd", time.Minute, CheckReadiness, func(context.Context) error {
+		return errUnhealthy
+	}); err != nil {
+		t.Fatalf("RegisterCheck: %v", err)
+	}
+	defer a.Shutdown()
+
+	waitForCheck(t, a, "etcd")
+
+	liveRec := httptest.NewRecorder()
+	a.LiveHandler(liveRec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if liveRec.Code != http.StatusOK {
+		t.Fatalf("/health/live status = %d, want %d", liveRec.Code, http.StatusOK)
+	}
+	liveBody := decodeAggregateResult(t, liveRec)
+	if !liveBody.Healthy {
+		t.Fatalf("/health/live body.Healthy = false, want true: %+v", liveBody)
+	}
+	if _, present := liveBody.Checks["etcd"]; present {
+		t.Fatalf("/health/live should not report a readiness-only check: %+v", liveBody)
+	}
+
+	readyRec := httptest.NewRecorder()
+	a.ReadyHandler(readyRec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if readyRec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/health/ready status = %d, want %d", readyRec.Code, http.StatusServiceUnavailable)
+	}
+	readyBody := decodeAggregateResult(t, readyRec)
+	if readyBody.Healthy {
+		t.Fatalf("/health/ready body.Healthy = true, want false: %+v", readyBody)
+	}
+	if got := readyBody.Checks["etcd"]; got.Healthy || got.LastError == "" {
+		t.Fatalf("/health/ready check result = %+v, want unhealthy with an error", got)
+	}
+}
+
+// TestHealthAggregator_LivenessFailureFailsBoth confirms a CheckLiveness
+// failure 503s both endpoints, since something that isn't alive can't be
+// ready either.
+func TestHealthAggregator_LivenessFailureFailsBoth(t *testing.T) {
+	a := &HealthAggregator{
+		statuses: make(map[string]*checkStatus),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+	if err := a.RegisterCheck("deadlock-detector", time.Minute, CheckLiveness, func(context.Context) error {
+		return errUnhealthy
+	}); err != nil {
+		t.Fatalf("RegisterCheck: %v", err)
+	}
+	defer a.Shutdown()
+
+	waitForCheck(t, a, "deadlock-detector")
+
+	for _, handler := range map[string]http.HandlerFunc{"live": a.LiveHandler, "ready": a.ReadyHandler} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+		body := decodeAggregateResult(t, rec)
+		if body.Healthy {
+			t.Fatalf("body.Healthy = true, want false: %+v", body)
+		}
+	}
+}
+
+var errUnhealthy = &staticError{"check failed"}
+
+type staticError struct{ msg string }
+
+func (e *staticError) Error() string { return e.msg }
+
+// waitForCheck polls until name has recorded at least one evaluation, since
+// RegisterCheck runs its first evaluation asynchronously.
+func waitForCheck(t *testing.T, a *HealthAggregator, name string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		a.mu.Lock()
+		status, ok := a.statuses[name]
+		evaluated := ok && (!status.lastSuccess.IsZero() || status.lastError != "")
+		a.mu.Unlock()
+		if evaluated {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("check %s was never evaluated", name)
+}
@@ -8,113 +8,197 @@ import (
 	"time"
 
 	"github.com/eapache/go-resiliency/retrier"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"svc.orchestrator/storage"
-	"svc.orchestrator/types"
 )
 
+// defaultHeartbeatInterval and maxHeartBeatRetries are used for any
+// registrant that doesn't set its own Interval/MaxRetries.
+const defaultHeartbeatInterval = 10 * time.Second
+
+// effectiveInterval returns info's configured heartbeat interval, falling
+// back to defaultHeartbeatInterval when unset.
+func effectiveInterval(info RegistrantConfig) time.Duration {
+	if info.Interval <= 0 {
+		return defaultHeartbeatInterval
+	}
+	return info.Interval
+}
+
+// effectiveMaxRetries returns info's configured retry budget, falling back
+// to maxHeartBeatRetries when unset.
+func effectiveMaxRetries(info RegistrantConfig) int {
+	if info.MaxRetries <= 0 {
+		return maxHeartBeatRetries
+	}
+	return info.MaxRetries
+}
+
+// missedHeartbeatDeadline is how long a push registrant may go without a
+// heartbeat before it's considered missed: N * interval, per its configured
+// max retries.
+func missedHeartbeatDeadline(info RegistrantConfig) time.Duration {
+	return effectiveInterval(info) * time.Duration(effectiveMaxRetries(info))
+}
+
 type healthChecker struct {
-	info       types.RegistrantInfo
+	info       RegistrantConfig
 	ticker     *time.Ticker
-	quit       chan struct{}
-	done       chan types.RegistrantInfo
-	client     clients.HeartbeatClient
+	cancel     context.CancelFunc
+	done       chan struct{}
+	err        error
+	transport  HeartbeatTransport
 	aggregator *MetricsAggregator
+	metrics    *Metrics
+	tracer     trace.Tracer
 }
 
-func newHealthChecker(info types.RegistrantInfo, done chan types.RegistrantInfo, aggregator *MetricsAggregator) *healthChecker {
-	client := clients.NewHeartbeatClient(info.ControlAddress)
-
-	r := healthChecker{
+func newHealthChecker(info RegistrantConfig, aggregator *MetricsAggregator, metrics *Metrics, push *PushCoordinator, tp TracerProvider) *healthChecker {
+	return &healthChecker{
 		info:       info,
-		done:       done,
-		client:     client,
-		quit:       make(chan struct{}),
+		transport:  newTransport(info, push),
+		done:       make(chan struct{}),
 		aggregator: aggregator,
+		metrics:    metrics,
+		tracer:     tracerOrNoop(tp),
 	}
+}
+
+// Start launches the checker's heartbeat loop under ctx. It returns once the
+// loop has been scheduled; callers should use Wait to block for completion.
+func (r *healthChecker) Start(ctx context.Context) error {
+	if r.cancel != nil {
+		return fmt.Errorf("healthcheck for %s already started", r.info.String())
+	}
+
+	checkCtx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
 
-	go r.startHealthCheck()
+	go r.runHealthCheck(checkCtx)
 
-	return &r
+	return nil
+}
+
+// Wait blocks until the checker has stopped and returns its terminal error,
+// if any.
+func (r *healthChecker) Wait() error {
+	<-r.done
+	return r.err
 }
 
-func (r *healthChecker) startHealthCheck() {
-	r.ticker = time.NewTicker(10 * time.Second)
-	retries := maxHeartBeatRetries
+func (r *healthChecker) runHealthCheck(ctx context.Context) {
+	defer close(r.done)
 	defer func() {
-		log.Printf("Stopping healthcheck for %s", r.info.String())
-		r.ticker.Stop()
-		log.Printf("Done stopping timer for %s", r.info.String())
-		r.done <- r.info
-		log.Printf("Done stopping healthcheck for %s", r.info.String())
+		if p := recover(); p != nil {
+			r.err = fmt.Errorf("healthcheck for %s panicked: %v", r.info.String(), p)
+			log.Printf("Recovered panic in healthcheck for %s: %v", r.info.String(), p)
+		}
 	}()
 
+	r.ticker = time.NewTicker(effectiveInterval(r.info))
+	defer r.ticker.Stop()
+
+	retries := effectiveMaxRetries(r.info)
+	r.metrics.setState(r.info.ServiceName, heartbeatStateInitializing)
+
 	log.Printf("Starting healthcheck for %s", r.info.String())
+	defer log.Printf("Stopping healthcheck for %s", r.info.String())
 
 	for retries > 0 {
 		select {
-		case <-r.quit:
-			log.Printf("Quiting healthcheck for %s", r.info.String())
+		case <-ctx.Done():
+			log.Printf("Context cancelled, stopping healthcheck for %s", r.info.String())
+			r.err = ctx.Err()
 			return
 		case <-r.ticker.C:
 			log.Printf("Sending heartbeat for %s (%s).......", r.info.ServiceName, r.info.ControlAddress)
-			if err := r.sendHeartBeat(); err != nil {
+			r.metrics.observeStarted(r.info.ServiceName)
+			start := time.Now()
+			if err := r.sendHeartBeat(ctx); err != nil {
 				retries--
 				log.Printf("Error sending heartbeat to service=%s (Retries remaining=%d! err=%s",
 					r.info.ServiceName, retries, err.Error())
+				r.err = err
+				r.metrics.observeFailed(r.info.ServiceName)
+				r.metrics.setState(r.info.ServiceName, heartbeatStateFailed)
+				if retries > 0 {
+					continue
+				}
+				r.metrics.observeRetriesExhausted(r.info.ServiceName)
 				return
-			} else {
-				retries = maxHeartBeatRetries
 			}
+			r.metrics.observeSucceeded(r.info.ServiceName, time.Since(start).Seconds())
+			r.metrics.setState(r.info.ServiceName, heartbeatStateNominal)
+			retries = effectiveMaxRetries(r.info)
 		}
 	}
 }
 
-func (r *healthChecker) stopHealthCheck() {
-	r.quit <- struct{}{}
+// Stop cancels the checker's context and waits for its loop to exit.
+func (r *healthChecker) Stop() error {
+	if r.cancel == nil {
+		return fmt.Errorf("healthcheck for %s was never started", r.info.String())
+	}
+	r.cancel()
+	return r.Wait()
 }
 
-func (r *healthChecker) sendHeartBeat() error {
+func (r *healthChecker) sendHeartBeat(ctx context.Context) error {
+	ctx, span := r.tracer.Start(ctx, "registry.sendHeartBeat", trace.WithAttributes(registrantServiceNameAttr(r.info.ServiceName)))
+	defer span.End()
+
 	var err error
 	var resp *clients.HeartbeatResponse
 	var expRetrier = retrier.New(retrier.ExponentialBackoff(4, 500*time.Millisecond), nil)
+	// Run makes one initial attempt plus one retry per backoff step.
+	const maxAttempts = 1 + 4
+	attempt := 0
 
 	if err := expRetrier.Run(func() error {
-		req := clients.HeartbeatRequest{}
+		attempt++
+		attemptCtx, attemptSpan := r.tracer.Start(ctx, "registry.sendHeartBeat.attempt", trace.WithAttributes(
+			registrantServiceNameAttr(r.info.ServiceName),
+			attribute.Int("retry.attempt", attempt),
+			attribute.Int("retry.max", maxAttempts),
+		))
 
-		resp, err = r.client.Heartbeat(context.Background(), &req)
-		if err != nil {
-			return err
-		}
+		resp, err = r.transport.Heartbeat(injectGRPCTraceContext(attemptCtx), r.info)
+		recordSpanError(attemptSpan, err)
+		attemptSpan.End()
 
-		return nil
+		return err
 	}); err != nil {
+		recordSpanError(span, err)
 		return err
 	}
 
 	if resp == nil {
-		return fmt.Errorf("hearteat failed for %s", r.info)
+		err := fmt.Errorf("hearteat failed for %s", r.info)
+		recordSpanError(span, err)
+		return err
 	}
 
 	for _, stats := range resp.Stats {
-		r.aggregator.AddDataPoint(&clients.DataPoint{
+		r.addDataPoint(ctx, &clients.DataPoint{
 			MetricID:  storage.MetricCPU,
 			ServiceID: stats.ServiceID,
 			TS:        stats.TS,
 			Value:     stats.CPU,
 		})
-		r.aggregator.AddDataPoint(&clients.DataPoint{
+		r.addDataPoint(ctx, &clients.DataPoint{
 			MetricID:  storage.MetricMemory,
 			ServiceID: stats.ServiceID,
 			TS:        stats.TS,
 			Value:     stats.Mem,
 		})
-		r.aggregator.AddDataPoint(&clients.DataPoint{
+		r.addDataPoint(ctx, &clients.DataPoint{
 			MetricID:  storage.MetricThreads,
 			ServiceID: stats.ServiceID,
 			TS:        stats.TS,
 			Value:     stats.Threads,
 		})
-		r.aggregator.AddDataPoint(&clients.DataPoint{
+		r.addDataPoint(ctx, &clients.DataPoint{
 			MetricID:  storage.MetricNumGoroutine,
 			ServiceID: stats.ServiceID,
 			TS:        stats.TS,
@@ -126,3 +210,16 @@ func (r *healthChecker) sendHeartBeat() error {
 
 	return nil
 }
+
+// addDataPoint records a single metric ingestion as its own child span,
+// since MetricsAggregator.AddDataPoint is the single sink for every
+// transport and a natural place to see ingestion latency per metric.
+func (r *healthChecker) addDataPoint(ctx context.Context, point *clients.DataPoint) {
+	_, span := r.tracer.Start(ctx, "registry.AddDataPoint", trace.WithAttributes(
+		registrantServiceNameAttr(r.info.ServiceName),
+		attribute.String("metric.id", fmt.Sprintf("%v", point.MetricID)),
+	))
+	defer span.End()
+
+	r.aggregator.AddDataPoint(point)
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"svc.orchestrator/types"
+)
+
+func newTestChecker(serviceName string) *healthChecker {
+	info := RegistrantConfig{
+		RegistrantInfo: types.RegistrantInfo{ServiceName: serviceName},
+		// Long enough that the ticker never fires during these tests, so
+		// Start/Stop semantics can be exercised without a live transport.
+		Interval:   time.Hour,
+		MaxRetries: 1,
+	}
+	return newHealthChecker(info, nil, nil, nil, nil)
+}
+
+func TestHealthChecker_StartThenStop(t *testing.T) {
+	checker := newTestChecker("svc-a")
+
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Stop cancels the checker's context, so its terminal error is expected
+	// to be context.Canceled rather than nil; what matters here is that it
+	// returns promptly instead of panicking or blocking forever.
+	_ = checker.Stop()
+}
+
+func TestHealthChecker_DoubleStartFails(t *testing.T) {
+	checker := newTestChecker("svc-b")
+
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("first Start: %v", err)
+	}
+	defer checker.Stop()
+
+	if err := checker.Start(context.Background()); err == nil {
+		t.Fatal("expected error starting an already-started checker")
+	}
+}
+
+func TestHealthChecker_StopBeforeStartFails(t *testing.T) {
+	checker := newTestChecker("svc-c")
+
+	if err := checker.Stop(); err == nil {
+		t.Fatal("expected error stopping a checker that was never started")
+	}
+}
+
+// TestHealthChecker_ConcurrentDoubleStop guards against the double-stop
+// panic the done-channel implementation used to have: calling Stop from
+// multiple goroutines must not panic or deadlock. Run with -race.
+func TestHealthChecker_ConcurrentDoubleStop(t *testing.T) {
+	checker := newTestChecker("svc-d")
+
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = checker.Stop()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("concurrent Stop calls did not return")
+	}
+}
+
+func TestCheckerDead(t *testing.T) {
+	checker := newTestChecker("svc-e")
+
+	if checkerDead(checker) {
+		t.Fatal("a checker that was never started should not report as dead")
+	}
+
+	if err := checker.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if checkerDead(checker) {
+		t.Fatal("a freshly-started checker should not report as dead")
+	}
+
+	_ = checker.Stop()
+	if !checkerDead(checker) {
+		t.Fatal("a stopped checker should report as dead")
+	}
+}
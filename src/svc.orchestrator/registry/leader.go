@@ -0,0 +1,195 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+	// leaderElectionKey is the etcd key campaigned on by every orchestrator
+	// replica wanting to own heartbeat collection.
+	leaderElectionKey = "/orchestrator/leader"
+
+	// defaultLeaseTTL is how long an unrenewed lease is honoured before the
+	// session is considered dead and leadership is released.
+	defaultLeaseTTL = 15 * time.Second
+)
+
+// Leader elects a single owner, among cooperating orchestrator replicas,
+// for heartbeat collection and metric aggregation.
+type Leader interface {
+	// Start campaigns for leadership and keeps renewing it until ctx is
+	// cancelled or Stop is called.
+	Start(ctx context.Context) error
+	// Stop resigns leadership, if held, and releases the underlying session.
+	Stop() error
+	// IsLeader reports whether this node currently holds leadership.
+	IsLeader() bool
+	// Changes emits true when leadership is gained and false when it is
+	// lost. The channel is buffered to 1 and always redelivers the most
+	// recent state rather than dropping it, so a slow consumer is
+	// guaranteed to observe the current leadership state even if it misses
+	// an intermediate flip. It is closed once Stop returns.
+	Changes() <-chan bool
+}
+
+// etcdLeader is an etcd-backed Leader using a lease-bound election, per
+// https://pkg.go.dev/go.etcd.io/etcd/client/v3/concurrency.
+type etcdLeader struct {
+	client   *clientv3.Client
+	nodeName string
+	leaseTTL time.Duration
+
+	isLeader atomic.Bool
+	changes  chan bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewEtcdLeader creates a Leader that campaigns on leaderElectionKey using
+// client, identifying itself as nodeName. leaseTTL defaults to
+// defaultLeaseTTL when zero.
+func NewEtcdLeader(client *clientv3.Client, nodeName string, leaseTTL time.Duration) Leader {
+	if leaseTTL <= 0 {
+		leaseTTL = defaultLeaseTTL
+	}
+
+	return &etcdLeader{
+		client:   client,
+		nodeName: nodeName,
+		leaseTTL: leaseTTL,
+		changes:  make(chan bool, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+func (l *etcdLeader) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	l.cancel = cancel
+
+	go l.run(runCtx)
+
+	return nil
+}
+
+func (l *etcdLeader) run(ctx context.Context) {
+	defer close(l.done)
+	defer close(l.changes)
+
+	attempt := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		session, err := concurrency.NewSession(l.client, concurrency.WithTTL(int(l.leaseTTL.Seconds())),
+			concurrency.WithContext(ctx))
+		if err != nil {
+			l.backoff(ctx, attempt)
+			attempt++
+			continue
+		}
+
+		election := concurrency.NewElection(session, leaderElectionKey)
+
+		if err := election.Campaign(ctx, l.nodeName); err != nil {
+			session.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			l.backoff(ctx, attempt)
+			attempt++
+			continue
+		}
+
+		attempt = 0
+		l.setLeader(true)
+		log.Printf("Acquired leadership of %s as %s", leaderElectionKey, l.nodeName)
+
+		select {
+		case <-ctx.Done():
+			l.resign(election)
+			session.Close()
+			l.setLeader(false)
+			return
+		case <-session.Done():
+			log.Printf("Lost leadership of %s: session expired", leaderElectionKey)
+			l.setLeader(false)
+		}
+	}
+}
+
+func (l *etcdLeader) resign(election *concurrency.Election) {
+	resignCtx, cancel := context.WithTimeout(context.Background(), l.leaseTTL)
+	defer cancel()
+	if err := election.Resign(resignCtx); err != nil {
+		log.Printf("Error resigning leadership of %s: %s", leaderElectionKey, err.Error())
+	}
+}
+
+// setLeader records isLeader and pushes it onto l.changes. The channel is
+// buffered to 1, but a plain non-blocking send would silently drop isLeader
+// if a previous, now-stale transition is still sitting unread in the
+// buffer: watchLeadership would then act on stale state and, if the
+// dropped transition was "lost leadership", a replica could keep writing
+// metrics while another replica also believes it's leader. Draining the
+// stale value before sending guarantees the buffer always holds the most
+// recent state instead.
+func (l *etcdLeader) setLeader(isLeader bool) {
+	l.isLeader.Store(isLeader)
+	for {
+		select {
+		case l.changes <- isLeader:
+			return
+		default:
+		}
+		select {
+		case <-l.changes:
+		default:
+		}
+	}
+}
+
+// backoff waits a jittered, exponentially increasing delay before the next
+// lease acquisition attempt, so a thundering herd of replicas doesn't
+// hammer etcd after a shared failure.
+func (l *etcdLeader) backoff(ctx context.Context, attempt int) {
+	base := time.Second << uint(attempt)
+	if base > 30*time.Second {
+		base = 30 * time.Second
+	}
+	jitter := time.Duration(rand.Int63n(int64(base)))
+	wait := base/2 + jitter/2
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(wait):
+	}
+}
+
+func (l *etcdLeader) Stop() error {
+	if l.cancel == nil {
+		return fmt.Errorf("leader election was never started")
+	}
+	l.cancel()
+	<-l.done
+	return nil
+}
+
+func (l *etcdLeader) IsLeader() bool {
+	return l.isLeader.Load()
+}
+
+func (l *etcdLeader) Changes() <-chan bool {
+	return l.changes
+}
@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestLeader() *etcdLeader {
+	return &etcdLeader{
+		nodeName: "test-node",
+		leaseTTL: defaultLeaseTTL,
+		changes:  make(chan bool, 1),
+		done:     make(chan struct{}),
+	}
+}
+
+// TestEtcdLeader_SetLeaderNeverBlocks drives setLeader through several
+// back-to-back transitions with nothing reading Changes() in between. The
+// old non-blocking select{...default:} send would silently drop every
+// transition after the first; setLeader must never block regardless, and
+// the buffer must end up holding the most recent state once a consumer
+// finally reads it.
+func TestEtcdLeader_SetLeaderNeverBlocks(t *testing.T) {
+	l := newTestLeader()
+
+	transitions := []bool{true, false, true, false, true}
+	done := make(chan struct{})
+	go func() {
+		for _, want := range transitions {
+			l.setLeader(want)
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("setLeader blocked on back-to-back transitions")
+	}
+
+	if got := l.IsLeader(); got != transitions[len(transitions)-1] {
+		t.Fatalf("IsLeader() = %v, want %v", got, transitions[len(transitions)-1])
+	}
+
+	select {
+	case got := <-l.Changes():
+		if got != transitions[len(transitions)-1] {
+			t.Fatalf("Changes() delivered %v, want most recent state %v", got, transitions[len(transitions)-1])
+		}
+	default:
+		t.Fatal("Changes() should have redelivered the most recent state")
+	}
+}
+
+// TestEtcdLeader_SetLeaderConcurrentWithConsumer guards against a deadlock
+// or data race (run with -race) between setLeader producing transitions and
+// a consumer draining Changes() concurrently.
+func TestEtcdLeader_SetLeaderConcurrentWithConsumer(t *testing.T) {
+	l := newTestLeader()
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-l.changes:
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		l.setLeader(i%2 == 0)
+	}
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer goroutine did not exit")
+	}
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Heartbeat states surfaced on the per-registrant state gauge.
+const (
+	heartbeatStateInitializing = 0
+	heartbeatStateNominal      = 1
+	heartbeatStateFailed       = 2
+)
+
+// Metrics holds the prometheus collectors tracking heartbeat health across
+// all registrants. It is safe for concurrent use.
+type Metrics struct {
+	state            *prometheus.GaugeVec
+	started          *prometheus.CounterVec
+	succeeded        *prometheus.CounterVec
+	failed           *prometheus.CounterVec
+	retriesExhausted *prometheus.CounterVec
+	rtt              *prometheus.HistogramVec
+}
+
+// NewMetrics registers the heartbeat collectors with reg and returns a
+// Metrics ready to be wired into newHealthChecker.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "state",
+			Help:      "Current heartbeat state per registrant (0=initializing, 1=nominal, 2=failed).",
+		}, []string{"service_name"}),
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "started_total",
+			Help:      "Heartbeats started per registrant.",
+		}, []string{"service_name"}),
+		succeeded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "succeeded_total",
+			Help:      "Heartbeats that completed successfully per registrant.",
+		}, []string{"service_name"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "failed_total",
+			Help:      "Heartbeats that failed per registrant.",
+		}, []string{"service_name"}),
+		retriesExhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "retries_exhausted_total",
+			Help:      "Times a registrant's heartbeat retries were exhausted.",
+		}, []string{"service_name"}),
+		rtt: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "orchestrator",
+			Subsystem: "heartbeat",
+			Name:      "rtt_seconds",
+			Help:      "Round-trip time of heartbeat requests per registrant.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service_name"}),
+	}
+
+	reg.MustRegister(m.state, m.started, m.succeeded, m.failed, m.retriesExhausted, m.rtt)
+
+	return m
+}
+
+func (m *Metrics) setState(serviceName string, state float64) {
+	if m == nil {
+		return
+	}
+	m.state.WithLabelValues(serviceName).Set(state)
+}
+
+func (m *Metrics) observeStarted(serviceName string) {
+	if m == nil {
+		return
+	}
+	m.started.WithLabelValues(serviceName).Inc()
+}
+
+func (m *Metrics) observeSucceeded(serviceName string, rttSeconds float64) {
+	if m == nil {
+		return
+	}
+	m.succeeded.WithLabelValues(serviceName).Inc()
+	m.rtt.WithLabelValues(serviceName).Observe(rttSeconds)
+}
+
+func (m *Metrics) observeFailed(serviceName string) {
+	if m == nil {
+		return
+	}
+	m.failed.WithLabelValues(serviceName).Inc()
+}
+
+func (m *Metrics) observeRetriesExhausted(serviceName string) {
+	if m == nil {
+		return
+	}
+	m.retriesExhausted.WithLabelValues(serviceName).Inc()
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gaugeValue(t *testing.T, m *Metrics, serviceName string) float64 {
+	t.Helper()
+	var out dto.Metric
+	if err := m.state.WithLabelValues(serviceName).Write(&out); err != nil {
+		t.Fatalf("reading state gauge: %v", err)
+	}
+	return out.GetGauge().GetValue()
+}
+
+func counterValue(t *testing.T, c *prometheus.CounterVec, serviceName string) float64 {
+	t.Helper()
+	var out dto.Metric
+	if err := c.WithLabelValues(serviceName).Write(&out); err != nil {
+		t.Fatalf("reading counter: %v", err)
+	}
+	return out.GetCounter().GetValue()
+}
+
+// TestMetrics_HeartbeatCycle drives a full started/succeeded heartbeat
+// through a Metrics backed by a local, isolated registry and asserts the
+// expected gauge/counter/histogram series update, keyed by service_name.
+func TestMetrics_HeartbeatCycle(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewMetrics(reg)
+
+	const service = "svc-a"
+
+	m.setState(service, heartbeatStateInitializing)
+	if got := gaugeValue(t, m, service); got != heartbeatStateInitializing {
+		t.Fatalf("state after setState(initializing) = %v, want %v", got, heartbeatStateInitializing)
+	}
+
+	m.observeStarted(service)
+	if got := counterValue(t, m.started, service); got != 1 {
+		t.Fatalf("started_total = %v, want 1", got)
+	}
+
+	m.observeSucceeded(service, 0.25)
+	if got := counterValue(t, m.succeeded, service); got != 1 {
+		t.Fatalf("succeeded_total = %v, want 1", got)
+	}
+	m.setState(service, heartbeatStateNominal)
+	if got := gaugeValue(t, m, service); got != heartbeatStateNominal {
+		t.Fatalf("state after succeed = %v, want %v", got, heartbeatStateNominal)
+	}
+
+	var rttMetric dto.Metric
+	if err := m.rtt.WithLabelValues(service).(prometheus.Histogram).Write(&rttMetric); err != nil {
+		t.Fatalf("reading rtt histogram: %v", err)
+	}
+	if got := rttMetric.GetHistogram().GetSampleCount(); got != 1 {
+		t.Fatalf("rtt_seconds sample count = %d, want 1", got)
+	}
+
+	m.observeFailed(service)
+	if got := counterValue(t, m.failed, service); got != 1 {
+		t.Fatalf("failed_total = %v, want 1", got)
+	}
+	m.setState(service, heartbeatStateFailed)
+	if got := gaugeValue(t, m, service); got != heartbeatStateFailed {
+		t.Fatalf("state after fail = %v, want %v", got, heartbeatStateFailed)
+	}
+
+	m.observeRetriesExhausted(service)
+	if got := counterValue(t, m.retriesExhausted, service); got != 1 {
+		t.Fatalf("retries_exhausted_total = %v, want 1", got)
+	}
+}
+
+// TestMetrics_NilReceiverIsNoop guards the pattern healthChecker relies on
+// throughout health_checker_test.go: a nil *Metrics must make every
+// set/observe call a safe no-op instead of panicking on a nil pointer deref.
+func TestMetrics_NilReceiverIsNoop(t *testing.T) {
+	var m *Metrics
+
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("nil *Metrics method panicked: %v", p)
+		}
+	}()
+
+	m.setState("svc", heartbeatStateNominal)
+	m.observeStarted("svc")
+	m.observeSucceeded("svc", 0.1)
+	m.observeFailed("svc")
+	m.observeRetriesExhausted("svc")
+}
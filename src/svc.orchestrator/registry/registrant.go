@@ -0,0 +1,36 @@
+package registry
+
+import (
+	"time"
+
+	"svc.orchestrator/types"
+)
+
+// Mode selects how a registrant's heartbeat is collected.
+type Mode string
+
+const (
+	ModePullGRPC Mode = "pull-grpc"
+	ModePullHTTP Mode = "pull-http"
+	ModePush     Mode = "push"
+)
+
+// RegistrantConfig augments types.RegistrantInfo with the transport,
+// interval, and retry knobs this package needs for pluggable heartbeat
+// transports. These live here, rather than on types.RegistrantInfo itself,
+// because svc.orchestrator/types is owned elsewhere and isn't touched by
+// this package's registration path.
+type RegistrantConfig struct {
+	types.RegistrantInfo
+
+	// Mode selects the HeartbeatTransport. The zero value is ModePullGRPC.
+	Mode Mode
+
+	// Interval is how often this registrant is polled (or, for push mode,
+	// how often it's expected to push). Zero means defaultHeartbeatInterval.
+	Interval time.Duration
+
+	// MaxRetries is how many consecutive heartbeat failures are tolerated
+	// before this registrant's checker stops. Zero means maxHeartBeatRetries.
+	MaxRetries int
+}
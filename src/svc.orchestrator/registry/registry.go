@@ -0,0 +1,339 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// reapInterval is how often the registry sweeps reg.checkers for dead
+// entries left behind by a checker that exhausted its retries or had its
+// context cancelled without going through Unregister/onLeadershipLost.
+const reapInterval = 30 * time.Second
+
+// checkerDead reports whether checker's loop has already exited.
+func checkerDead(checker *healthChecker) bool {
+	select {
+	case <-checker.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// HydrateFunc loads the current set of registrants from durable storage. It
+// is called whenever this node gains leadership, so a freshly-elected
+// replica resumes heartbeat collection without waiting for new registration
+// calls.
+type HydrateFunc func(ctx context.Context) ([]RegistrantConfig, error)
+
+// Registry tracks the set of live registrants and supervises a
+// healthChecker for each of them.
+//
+// When a Leader is configured, only the elected node runs checkers: on
+// leadership loss all active checkers are cancelled, and on leadership gain
+// the registrant set is re-hydrated via HydrateFunc. Without a Leader the
+// registry always behaves as if it holds leadership, for single-node
+// deployments.
+type Registry struct {
+	mu       sync.Mutex
+	wanted   map[string]RegistrantConfig
+	checkers map[string]*healthChecker
+
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+
+	aggregator *MetricsAggregator
+	metrics    *Metrics
+
+	leader         Leader
+	hydrate        HydrateFunc
+	push           *PushCoordinator
+	tracerProvider TracerProvider
+}
+
+// Option configures optional Registry behaviour. The zero value of a
+// Registry built without any options is a single-node registry with no
+// metrics and no support for push-mode registrants.
+type Option func(*Registry)
+
+// WithMetrics records heartbeat metrics for every registrant via m.
+func WithMetrics(m *Metrics) Option {
+	return func(reg *Registry) { reg.metrics = m }
+}
+
+// WithLeaderElection restricts heartbeat collection to whichever replica
+// holds leadership, re-hydrating the registrant set via hydrate whenever
+// this node is elected.
+func WithLeaderElection(leader Leader, hydrate HydrateFunc) Option {
+	return func(reg *Registry) {
+		reg.leader = leader
+		reg.hydrate = hydrate
+	}
+}
+
+// WithPushCoordinator lets registrants in ModePush POST their stats to push
+// instead of being polled.
+func WithPushCoordinator(push *PushCoordinator) Option {
+	return func(reg *Registry) { reg.push = push }
+}
+
+// WithTracerProvider exports heartbeat and metric-ingestion spans via tp.
+// Without this option, tracing is a no-op.
+func WithTracerProvider(tp TracerProvider) Option {
+	return func(reg *Registry) { reg.tracerProvider = tp }
+}
+
+// NewRegistry creates a Registry whose registrants run under a root context
+// derived from ctx. Cancelling ctx (or calling Shutdown) stops every
+// registrant's healthChecker.
+func NewRegistry(ctx context.Context, aggregator *MetricsAggregator, opts ...Option) *Registry {
+	rootCtx, rootCancel := context.WithCancel(ctx)
+
+	reg := &Registry{
+		wanted:     make(map[string]RegistrantConfig),
+		checkers:   make(map[string]*healthChecker),
+		rootCtx:    rootCtx,
+		rootCancel: rootCancel,
+		aggregator: aggregator,
+	}
+
+	for _, opt := range opts {
+		opt(reg)
+	}
+
+	go reg.runReaper(rootCtx)
+
+	return reg
+}
+
+// runReaper periodically removes dead checkers from reg.checkers and, if
+// this node still holds leadership and the registrant is still wanted,
+// restarts them. Without this, a registrant whose checker exhausts its
+// retries (or panics) stays a "zombie" entry that keeps failing Healthy()
+// until this node next gains leadership, the only time onLeadershipGained
+// replaces dead checkers.
+func (reg *Registry) runReaper(ctx context.Context) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reg.reapDeadCheckers()
+		}
+	}
+}
+
+func (reg *Registry) reapDeadCheckers() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for name, checker := range reg.checkers {
+		if !checkerDead(checker) {
+			continue
+		}
+
+		if checker.err != nil {
+			log.Printf("Reaping dead healthcheck for %s: %s", checker.info.String(), checker.err.Error())
+		} else {
+			log.Printf("Reaping dead healthcheck for %s", checker.info.String())
+		}
+		delete(reg.checkers, name)
+
+		if info, wanted := reg.wanted[name]; wanted && reg.IsLeader() {
+			reg.startCheckerLocked(info)
+		}
+	}
+}
+
+// Start begins watching for leadership changes, when a Leader is configured.
+// Without a Leader, Start is a no-op and the registry behaves as a
+// perpetual leader.
+func (reg *Registry) Start(ctx context.Context) error {
+	if reg.leader == nil {
+		return nil
+	}
+
+	if err := reg.leader.Start(ctx); err != nil {
+		return err
+	}
+
+	go reg.watchLeadership(ctx)
+
+	return nil
+}
+
+func (reg *Registry) watchLeadership(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case gained, ok := <-reg.leader.Changes():
+			if !ok {
+				return
+			}
+			if gained {
+				reg.onLeadershipGained(ctx)
+			} else {
+				reg.onLeadershipLost()
+			}
+		}
+	}
+}
+
+func (reg *Registry) onLeadershipGained(ctx context.Context) {
+	log.Printf("Gained leadership, re-hydrating registrants")
+
+	if reg.hydrate != nil {
+		infos, err := reg.hydrate(ctx)
+		if err != nil {
+			log.Printf("Error hydrating registrants after gaining leadership: %s", err.Error())
+		} else {
+			reg.mu.Lock()
+			for _, info := range infos {
+				reg.wanted[info.ServiceName] = info
+			}
+			reg.mu.Unlock()
+		}
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for name, info := range reg.wanted {
+		if checker, present := reg.checkers[name]; present {
+			if !checkerDead(checker) {
+				continue
+			}
+			delete(reg.checkers, name)
+		}
+		reg.startCheckerLocked(info)
+	}
+}
+
+func (reg *Registry) onLeadershipLost() {
+	log.Printf("Lost leadership, stopping active healthcheckers")
+
+	reg.mu.Lock()
+	checkers := make([]*healthChecker, 0, len(reg.checkers))
+	for name, checker := range reg.checkers {
+		checkers = append(checkers, checker)
+		delete(reg.checkers, name)
+	}
+	reg.mu.Unlock()
+
+	for _, checker := range checkers {
+		if err := checker.Stop(); err != nil {
+			log.Printf("Error stopping healthcheck for %s after losing leadership: %s", checker.info.String(), err.Error())
+		}
+	}
+}
+
+// IsLeader reports whether this node currently owns heartbeat collection.
+// Nodes without a configured Leader are always considered leader.
+func (reg *Registry) IsLeader() bool {
+	if reg.leader == nil {
+		return true
+	}
+	return reg.leader.IsLeader()
+}
+
+// startCheckerLocked starts a healthChecker for info. reg.mu must be held.
+func (reg *Registry) startCheckerLocked(info RegistrantConfig) {
+	checker := newHealthChecker(info, reg.aggregator, reg.metrics, reg.push, reg.tracerProvider)
+	if err := checker.Start(reg.rootCtx); err != nil {
+		log.Printf("Error starting healthcheck for %s: %s", info.String(), err.Error())
+		return
+	}
+	reg.checkers[info.ServiceName] = checker
+}
+
+// Register records info as a desired registrant and, if this node currently
+// holds leadership, starts its healthChecker immediately.
+func (reg *Registry) Register(info RegistrantConfig) error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, exists := reg.wanted[info.ServiceName]; exists {
+		return fmt.Errorf("registrant %s is already registered", info.ServiceName)
+	}
+	reg.wanted[info.ServiceName] = info
+
+	if reg.IsLeader() {
+		reg.startCheckerLocked(info)
+	}
+
+	return nil
+}
+
+// Unregister stops and removes the healthChecker for the given service name.
+func (reg *Registry) Unregister(serviceName string) error {
+	reg.mu.Lock()
+	if _, exists := reg.wanted[serviceName]; !exists {
+		reg.mu.Unlock()
+		return fmt.Errorf("registrant %s is not registered", serviceName)
+	}
+	delete(reg.wanted, serviceName)
+
+	checker, running := reg.checkers[serviceName]
+	if running {
+		delete(reg.checkers, serviceName)
+	}
+	reg.mu.Unlock()
+
+	if !running {
+		return nil
+	}
+
+	return checker.Stop()
+}
+
+// Healthy returns an error if any tracked healthChecker has stopped (with its
+// terminal error, when it has one), and nil if every one is still running.
+func (reg *Registry) Healthy() error {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	for _, checker := range reg.checkers {
+		select {
+		case <-checker.done:
+			if checker.err != nil {
+				return fmt.Errorf("registrant %s: %w", checker.info.String(), checker.err)
+			}
+			return fmt.Errorf("registrant %s is no longer running", checker.info.String())
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Shutdown cancels the root context for all registrants, stops leader
+// election, and waits for every healthChecker to drain before returning.
+func (reg *Registry) Shutdown() {
+	reg.rootCancel()
+
+	if reg.leader != nil {
+		if err := reg.leader.Stop(); err != nil {
+			log.Printf("Error stopping leader election: %s", err.Error())
+		}
+	}
+
+	reg.mu.Lock()
+	checkers := make([]*healthChecker, 0, len(reg.checkers))
+	for name, checker := range reg.checkers {
+		checkers = append(checkers, checker)
+		delete(reg.checkers, name)
+	}
+	reg.mu.Unlock()
+
+	for _, checker := range checkers {
+		if err := checker.Wait(); err != nil {
+			log.Printf("Healthcheck for %s stopped with error: %s", checker.info.String(), err.Error())
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package registry
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"svc.orchestrator/types"
+)
+
+// fakeLeader is a test-controlled Leader: the test drives Changes() directly
+// instead of going through a real etcd session.
+type fakeLeader struct {
+	isLeader atomic.Bool
+	changes  chan bool
+}
+
+func newFakeLeader() *fakeLeader {
+	return &fakeLeader{changes: make(chan bool, 1)}
+}
+
+func (f *fakeLeader) Start(ctx context.Context) error { return nil }
+func (f *fakeLeader) Stop() error                     { close(f.changes); return nil }
+func (f *fakeLeader) IsLeader() bool                  { return f.isLeader.Load() }
+func (f *fakeLeader) Changes() <-chan bool            { return f.changes }
+
+// setLeader pushes a transition onto the buffered Changes() channel. It
+// returns without waiting for watchLeadership to read it, so callers wait
+// for its effect with waitForCondition.
+func (f *fakeLeader) setLeader(isLeader bool) {
+	f.isLeader.Store(isLeader)
+	f.changes <- isLeader
+}
+
+func testRegistrant(serviceName string) RegistrantConfig {
+	return RegistrantConfig{
+		RegistrantInfo: types.RegistrantInfo{ServiceName: serviceName},
+		// Long enough that the ticker never fires during these tests, so
+		// heartbeats are never actually sent.
+		Interval:   time.Hour,
+		MaxRetries: 1,
+	}
+}
+
+func waitForCondition(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !cond() {
+		t.Fatal("condition was never met")
+	}
+}
+
+// TestRegistry_ReapAndRestartDeadChecker drives a fake Leader through a
+// leadership gain, kills a checker out from under the Registry by cancelling
+// its context, which, like exhausted retries or a panic, ends its loop
+// without going through Unregister, and asserts reapDeadCheckers both removes the
+// zombie entry and restarts it, since the registrant is still wanted and
+// this node is still leader.
+func TestRegistry_ReapAndRestartDeadChecker(t *testing.T) {
+	leader := newFakeLeader()
+	hydrate := func(ctx context.Context) ([]RegistrantConfig, error) {
+		return []RegistrantConfig{testRegistrant("svc-a")}, nil
+	}
+
+	reg := NewRegistry(context.Background(), nil, WithLeaderElection(leader, hydrate))
+	defer reg.Shutdown()
+
+	if err := reg.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	leader.setLeader(true)
+
+	var original *healthChecker
+	waitForCondition(t, time.Second, func() bool {
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+		original = reg.checkers["svc-a"]
+		return original != nil
+	})
+
+	// Simulate the checker dying on its own (exhausted retries, panic)
+	// without going through Unregister: its done channel closes, but
+	// nothing has removed it from reg.checkers yet.
+	original.cancel()
+	waitForCondition(t, time.Second, func() bool {
+		return checkerDead(original)
+	})
+
+	reg.reapDeadCheckers()
+
+	reg.mu.Lock()
+	restarted := reg.checkers["svc-a"]
+	reg.mu.Unlock()
+
+	if restarted == nil {
+		t.Fatal("reapDeadCheckers should have restarted the still-wanted registrant")
+	}
+	if restarted == original {
+		t.Fatal("reapDeadCheckers should have started a fresh checker, not kept the dead one")
+	}
+	if checkerDead(restarted) {
+		t.Fatal("the restarted checker should not already be dead")
+	}
+}
+
+// TestRegistry_LosingLeadershipStopsAllCheckers drives a fake Leader through
+// a gain followed by a loss and asserts every active checker is stopped and
+// removed from reg.checkers.
+func TestRegistry_LosingLeadershipStopsAllCheckers(t *testing.T) {
+	leader := newFakeLeader()
+	hydrate := func(ctx context.Context) ([]RegistrantConfig, error) {
+		return []RegistrantConfig{testRegistrant("svc-a"), testRegistrant("svc-b")}, nil
+	}
+
+	reg := NewRegistry(context.Background(), nil, WithLeaderElection(leader, hydrate))
+	defer reg.Shutdown()
+
+	if err := reg.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	leader.setLeader(true)
+	waitForCondition(t, time.Second, func() bool {
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+		return len(reg.checkers) == 2
+	})
+
+	leader.setLeader(false)
+	waitForCondition(t, time.Second, func() bool {
+		reg.mu.Lock()
+		defer reg.mu.Unlock()
+		return len(reg.checkers) == 0
+	})
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// TracerProvider is the config knob for where heartbeat and metric-ingestion
+// spans are exported to. It defaults to a no-op provider, so tracing is
+// opt-in: set it to a real provider (Jaeger, Zipkin, ...) from orchestrator
+// startup to enable it.
+type TracerProvider = trace.TracerProvider
+
+const instrumentationName = "svc.orchestrator/registry"
+
+// tracerOrNoop returns tp's tracer, or a no-op tracer when tp is nil.
+func tracerOrNoop(tp TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = trace.NewNoopTracerProvider()
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+// grpcMetadataCarrier adapts grpc metadata.MD to propagation.TextMapCarrier
+// so the active span context can be injected into outgoing heartbeat
+// requests.
+type grpcMetadataCarrier metadata.MD
+
+var _ propagation.TextMapCarrier = grpcMetadataCarrier{}
+
+func (c grpcMetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c grpcMetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c grpcMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// injectGRPCTraceContext propagates the span context active on ctx into
+// outgoing gRPC metadata, so a registrant's own spans for this request
+// attach to the same trace.
+func injectGRPCTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+
+	otel.GetTextMapPropagator().Inject(ctx, grpcMetadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// registrantServiceNameAttr tags a span with the registrant being polled.
+// This is deliberately not the OTel semconv "service.name" key: that key is
+// reserved for the identity of the service emitting the span (the
+// orchestrator itself), and reusing it here would show a confusing,
+// registrant-dependent service name in Jaeger/Zipkin instead.
+func registrantServiceNameAttr(serviceName string) attribute.KeyValue {
+	return attribute.String("registrant.service_name", serviceName)
+}
@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/metadata"
+)
+
+// TestInjectGRPCTraceContext_Propagates asserts that a span context active
+// on ctx ends up on the outgoing gRPC metadata, so a registrant's own spans
+// for a heartbeat request attach to the same trace.
+func TestInjectGRPCTraceContext_Propagates(t *testing.T) {
+	prevPropagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(prevPropagator)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(instrumentationName)
+
+	ctx, span := tracer.Start(context.Background(), "test-span")
+	defer span.End()
+
+	outCtx := injectGRPCTraceContext(ctx)
+
+	md, ok := metadata.FromOutgoingContext(outCtx)
+	if !ok {
+		t.Fatal("expected outgoing gRPC metadata to be set")
+	}
+	if len(md.Get("traceparent")) == 0 {
+		t.Fatalf("expected traceparent to be injected into metadata, got: %v", md)
+	}
+}
+
+// TestRecordSpanError asserts recordSpanError sets span status/error: Ok on
+// nil, Error (with the error recorded) otherwise.
+func TestRecordSpanError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(instrumentationName)
+
+	_, okSpan := tracer.Start(context.Background(), "ok-span")
+	recordSpanError(okSpan, nil)
+	okSpan.End()
+
+	_, failSpan := tracer.Start(context.Background(), "fail-span")
+	recordSpanError(failSpan, errUnhealthy)
+	failSpan.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d ended spans, want 2", len(spans))
+	}
+
+	byName := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		byName[s.Name()] = s
+	}
+
+	if got := byName["ok-span"].Status().Code; got != codes.Ok {
+		t.Fatalf("ok-span status = %v, want %v", got, codes.Ok)
+	}
+	if got := byName["fail-span"].Status().Code; got != codes.Error {
+		t.Fatalf("fail-span status = %v, want %v", got, codes.Error)
+	}
+	if events := byName["fail-span"].Events(); len(events) == 0 {
+		t.Fatal("fail-span should have a recorded error event")
+	}
+}
+
+// TestRegistrantServiceNameAttr asserts the attribute key is deliberately
+// not the reserved OTel semconv "service.name", per the regression this
+// attribute was renamed to fix.
+func TestRegistrantServiceNameAttr(t *testing.T) {
+	attr := registrantServiceNameAttr("svc-a")
+
+	if got, want := string(attr.Key), "registrant.service_name"; got != want {
+		t.Fatalf("attribute key = %q, want %q", got, want)
+	}
+	if got, want := attr.Value.AsString(), "svc-a"; got != want {
+		t.Fatalf("attribute value = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package registry
+
+import (
+	"clients"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HeartbeatTransport fetches the current stats for a registrant, regardless
+// of whether they were pulled over the wire or pushed by the registrant
+// ahead of time. Every implementation feeds the same
+// *clients.HeartbeatResponse shape, so MetricsAggregator.AddDataPoint stays
+// the single sink regardless of transport.
+type HeartbeatTransport interface {
+	Heartbeat(ctx context.Context, info RegistrantConfig) (*clients.HeartbeatResponse, error)
+}
+
+// newTransport selects the HeartbeatTransport for info.Mode. push may be nil
+// when the orchestrator doesn't accept pushed heartbeats; registrants with
+// Mode ModePush will then fail every heartbeat.
+func newTransport(info RegistrantConfig, push *PushCoordinator) HeartbeatTransport {
+	switch info.Mode {
+	case ModePullHTTP:
+		return &httpTransport{httpClient: http.DefaultClient}
+	case ModePush:
+		return &pushTransport{coordinator: push}
+	default:
+		return &grpcTransport{client: clients.NewHeartbeatClient(info.ControlAddress)}
+	}
+}
+
+// grpcTransport is the original pull transport, unchanged from before
+// transports were made pluggable.
+type grpcTransport struct {
+	client clients.HeartbeatClient
+}
+
+func (t *grpcTransport) Heartbeat(ctx context.Context, _ RegistrantConfig) (*clients.HeartbeatResponse, error) {
+	return t.client.Heartbeat(ctx, &clients.HeartbeatRequest{})
+}
+
+// httpTransport pulls heartbeat stats from a registrant's control address
+// over HTTP/JSON instead of gRPC.
+type httpTransport struct {
+	httpClient *http.Client
+}
+
+func (t *httpTransport) Heartbeat(ctx context.Context, info RegistrantConfig) (*clients.HeartbeatResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, info.ControlAddress+"/v1/heartbeat", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("heartbeat request to %s returned status %d", info.ControlAddress, resp.StatusCode)
+	}
+
+	var out clients.HeartbeatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding heartbeat response from %s: %w", info.ControlAddress, err)
+	}
+
+	return &out, nil
+}
+
+// pushRecord is the last heartbeat a registrant POSTed to the orchestrator.
+type pushRecord struct {
+	at   time.Time
+	resp *clients.HeartbeatResponse
+}
+
+// PushCoordinator receives heartbeats POSTed by registrants running in
+// ModePush and hands them to pushTransport on the next poll. It is the
+// counterpart to grpc/http pull: instead of the orchestrator dialing out,
+// registrants dial in and the coordinator keeps the most recent sample per
+// registrant until it goes stale.
+type PushCoordinator struct {
+	mu       sync.Mutex
+	received map[string]*pushRecord
+}
+
+// NewPushCoordinator creates an empty PushCoordinator.
+func NewPushCoordinator() *PushCoordinator {
+	return &PushCoordinator{received: make(map[string]*pushRecord)}
+}
+
+type pushHeartbeatBody struct {
+	ServiceName string         `json:"service_name"`
+	Stats       []clients.Stat `json:"stats"`
+}
+
+// Handler serves POST /v1/heartbeat: registrants call this instead of being
+// polled.
+func (p *PushCoordinator) Handler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body pushHeartbeatBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("decoding push heartbeat: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	p.received[body.ServiceName] = &pushRecord{
+		at:   time.Now(),
+		resp: &clients.HeartbeatResponse{Stats: body.Stats},
+	}
+	p.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// take reports the most recent push for serviceName, failing if none has
+// ever arrived or the last one is older than deadline. A push record is not
+// consumed on read: it stays live, and keeps being reported as healthy,
+// until it actually goes stale. That's what makes deadline (N * interval)
+// the sole arbiter of a missed push heartbeat, independent of how often
+// it's polled or how the outer retry loop is configured.
+func (p *PushCoordinator) take(serviceName string, deadline time.Duration) (*clients.HeartbeatResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record, ok := p.received[serviceName]
+	if !ok {
+		return nil, fmt.Errorf("no heartbeat pushed yet for %s", serviceName)
+	}
+	if age := time.Since(record.at); age > deadline {
+		return nil, fmt.Errorf("missed heartbeat for %s: last push was %s ago", serviceName, age)
+	}
+
+	return record.resp, nil
+}
+
+// pushTransport adapts PushCoordinator to HeartbeatTransport so healthChecker
+// can treat push registrants identically to polled ones.
+type pushTransport struct {
+	coordinator *PushCoordinator
+}
+
+func (t *pushTransport) Heartbeat(_ context.Context, info RegistrantConfig) (*clients.HeartbeatResponse, error) {
+	if t.coordinator == nil {
+		return nil, fmt.Errorf("push heartbeats are not accepted by this orchestrator")
+	}
+	return t.coordinator.take(info.ServiceName, missedHeartbeatDeadline(info))
+}
@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clients"
+	"svc.orchestrator/types"
+)
+
+func TestPushCoordinator_Take(t *testing.T) {
+	p := NewPushCoordinator()
+
+	if _, err := p.take("svc-a", time.Minute); err == nil {
+		t.Fatal("expected an error before any heartbeat has been pushed")
+	}
+
+	p.received["svc-a"] = &pushRecord{
+		at:   time.Now(),
+		resp: &clients.HeartbeatResponse{Stats: []clients.Stat{{ServiceID: "svc-a"}}},
+	}
+
+	resp, err := p.take("svc-a", time.Minute)
+	if err != nil {
+		t.Fatalf("take while fresh: %v", err)
+	}
+	if len(resp.Stats) != 1 || resp.Stats[0].ServiceID != "svc-a" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	// A push record is not consumed on read: a second take before the
+	// deadline must still see it. This pins the fix to the bug where take
+	// deleted the record on every successful read, so a push registrant
+	// failed on the very next poll tick instead of after its configured
+	// deadline.
+	resp, err = p.take("svc-a", time.Minute)
+	if err != nil {
+		t.Fatalf("second take before deadline: %v", err)
+	}
+	if len(resp.Stats) != 1 {
+		t.Fatalf("unexpected response on second read: %+v", resp)
+	}
+
+	p.mu.Lock()
+	p.received["svc-a"].at = time.Now().Add(-time.Minute)
+	p.mu.Unlock()
+
+	if _, err := p.take("svc-a", 500*time.Millisecond); err == nil {
+		t.Fatal("expected an error once the last push is older than the deadline")
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	push := NewPushCoordinator()
+
+	cases := []struct {
+		name string
+		mode Mode
+		push *PushCoordinator
+		want interface{}
+	}{
+		{name: "zero value defaults to grpc pull", mode: "", push: nil, want: &grpcTransport{}},
+		{name: "pull-grpc", mode: ModePullGRPC, push: nil, want: &grpcTransport{}},
+		{name: "pull-http", mode: ModePullHTTP, push: nil, want: &httpTransport{}},
+		{name: "push", mode: ModePush, push: push, want: &pushTransport{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			info := RegistrantConfig{
+				RegistrantInfo: types.RegistrantInfo{ServiceName: "svc", ControlAddress: "addr"},
+				Mode:           tc.mode,
+			}
+
+			got := newTransport(info, tc.push)
+
+			switch tc.want.(type) {
+			case *grpcTransport:
+				if _, ok := got.(*grpcTransport); !ok {
+					t.Fatalf("newTransport(%q) = %T, want *grpcTransport", tc.mode, got)
+				}
+			case *httpTransport:
+				if _, ok := got.(*httpTransport); !ok {
+					t.Fatalf("newTransport(%q) = %T, want *httpTransport", tc.mode, got)
+				}
+			case *pushTransport:
+				pt, ok := got.(*pushTransport)
+				if !ok {
+					t.Fatalf("newTransport(%q) = %T, want *pushTransport", tc.mode, got)
+				}
+				if pt.coordinator != tc.push {
+					t.Fatal("pushTransport should wrap the given PushCoordinator")
+				}
+			}
+		})
+	}
+}
+
+// TestPushTransport_NoCoordinator pins the "not accepted" error path a
+// ModePush registrant hits when the orchestrator wasn't configured with a
+// PushCoordinator.
+func TestPushTransport_NoCoordinator(t *testing.T) {
+	transport := &pushTransport{coordinator: nil}
+
+	info := RegistrantConfig{RegistrantInfo: types.RegistrantInfo{ServiceName: "svc"}}
+
+	if _, err := transport.Heartbeat(context.Background(), info); err == nil {
+		t.Fatal("expected an error when no PushCoordinator is configured")
+	}
+}